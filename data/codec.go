@@ -0,0 +1,267 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CodecID identifies which codec a payload was compressed with, so that
+// entries written before a codec change (or rollout) remain readable.
+type CodecID byte
+
+const (
+	CodecNone   CodecID = 0
+	CodecSnappy CodecID = 1
+	CodecZstd   CodecID = 2
+)
+
+// CompressionConfig selects the codec used to compress cache payloads before
+// they're handed to the underlying Connector, and optionally a shared zstd
+// dictionary trained on representative responses for a given network.
+//
+// Changing ZstdDictPath to point at a different dictionary makes entries
+// written under the old one undecodable; CompressingConnector.Get treats that
+// as a plain cache miss rather than a failed read, so rotation is safe but effectively flushes zstd-compressed entries over time
+// as they're naturally re-fetched and re-written under the new dictionary.
+type CompressionConfig struct {
+	Codec        string // "none" (default), "snappy", or "zstd"
+	ZstdDictPath string // optional path to a trained zstd dictionary
+}
+
+// payloadHeader is the small fixed header prefixed to every compressed value
+// so a reader knows how to decompress it without any side-channel state.
+// DictID is recorded for diagnostics only; codecRegistry holds a single zstd
+// codec built from the active CompressionConfig, so it isn't consulted to
+// pick a decoder - a payload written under a different dictionary simply
+// fails to decode and is treated as a cache miss.
+type payloadHeader struct {
+	CodecID         CodecID
+	DictID          uint32
+	UncompressedLen uint32
+}
+
+const payloadHeaderLen = 1 + 4 + 4
+
+func encodeHeader(h payloadHeader) []byte {
+	buf := make([]byte, payloadHeaderLen)
+	buf[0] = byte(h.CodecID)
+	putUint32(buf[1:5], h.DictID)
+	putUint32(buf[5:9], h.UncompressedLen)
+	return buf
+}
+
+func decodeHeader(b []byte) (payloadHeader, []byte, error) {
+	if len(b) < payloadHeaderLen {
+		return payloadHeader{}, nil, fmt.Errorf("cache payload too short to contain a codec header: %d bytes", len(b))
+	}
+	h := payloadHeader{
+		CodecID:         CodecID(b[0]),
+		DictID:          getUint32(b[1:5]),
+		UncompressedLen: getUint32(b[5:9]),
+	}
+	return h, b[payloadHeaderLen:], nil
+}
+
+func putUint32(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 24)
+}
+
+func getUint32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// codec compresses and decompresses cache payload bytes for a single CodecID.
+type codec interface {
+	id() CodecID
+	compress(dictID uint32, src []byte) ([]byte, error)
+	decompress(dictID uint32, src []byte) ([]byte, error)
+}
+
+type noneCodec struct{}
+
+func (noneCodec) id() CodecID                                     { return CodecNone }
+func (noneCodec) compress(_ uint32, src []byte) ([]byte, error)   { return src, nil }
+func (noneCodec) decompress(_ uint32, src []byte) ([]byte, error) { return src, nil }
+
+type snappyCodec struct{}
+
+func (snappyCodec) id() CodecID { return CodecSnappy }
+func (snappyCodec) compress(_ uint32, src []byte) ([]byte, error) {
+	return snappy.Encode(nil, src), nil
+}
+func (snappyCodec) decompress(_ uint32, src []byte) ([]byte, error) {
+	return snappy.Decode(nil, src)
+}
+
+type zstdCodec struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCodec(dictPath string) (*zstdCodec, error) {
+	var encOpts []zstd.EOption
+	var decOpts []zstd.DOption
+	if dictPath != "" {
+		dict, err := os.ReadFile(dictPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading zstd dictionary %q: %w", dictPath, err)
+		}
+		encOpts = append(encOpts, zstd.WithEncoderDict(dict))
+		decOpts = append(decOpts, zstd.WithDecoderDicts(dict))
+	}
+
+	enc, err := zstd.NewWriter(nil, encOpts...)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil, decOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdCodec{encoder: enc, decoder: dec}, nil
+}
+
+func (z *zstdCodec) id() CodecID { return CodecZstd }
+func (z *zstdCodec) compress(_ uint32, src []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(src, nil), nil
+}
+func (z *zstdCodec) decompress(_ uint32, src []byte) ([]byte, error) {
+	return z.decoder.DecodeAll(src, nil)
+}
+
+// codecRegistry resolves a CodecID to its implementation, used on the read
+// path so old entries compressed with a previous codec remain readable
+// regardless of what the current CompressionConfig selects for new writes.
+type codecRegistry struct {
+	active codec
+	byID   map[CodecID]codec
+}
+
+func newCodecRegistry(cfg *CompressionConfig) (*codecRegistry, error) {
+	none := noneCodec{}
+	snp := snappyCodec{}
+	zst, err := newZstdCodec(cfg.ZstdDictPath)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := &codecRegistry{
+		byID: map[CodecID]codec{
+			CodecNone:   none,
+			CodecSnappy: snp,
+			CodecZstd:   zst,
+		},
+	}
+
+	switch cfg.Codec {
+	case "", "none":
+		reg.active = none
+	case "snappy":
+		reg.active = snp
+	case "zstd":
+		reg.active = zst
+	default:
+		return nil, fmt.Errorf("unknown cache compression codec %q", cfg.Codec)
+	}
+
+	return reg, nil
+}
+
+func (r *codecRegistry) encode(src []byte) ([]byte, error) {
+	compressed, err := r.active.compress(0, src)
+	if err != nil {
+		return nil, err
+	}
+	header := encodeHeader(payloadHeader{
+		CodecID:         r.active.id(),
+		DictID:          0,
+		UncompressedLen: uint32(len(src)),
+	})
+	return append(header, compressed...), nil
+}
+
+func (r *codecRegistry) decode(src []byte) ([]byte, error) {
+	header, body, err := decodeHeader(src)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := r.byID[header.CodecID]
+	if !ok {
+		return nil, fmt.Errorf("cache payload uses unknown codec id %d", header.CodecID)
+	}
+	return c.decompress(header.DictID, body)
+}
+
+// CompressingConnector wraps a Connector, transparently compressing values on
+// Set and decompressing on Get. Values written by a prior codec (including
+// raw, pre-header JSON) remain readable; see isLegacyPayload.
+type CompressingConnector struct {
+	Connector
+	codecs *codecRegistry
+}
+
+// NewCompressingConnector wraps conn so that every value written through it
+// is compressed per cfg, while values written before compression was enabled
+// (or with a different codec) are still read back correctly.
+func NewCompressingConnector(conn Connector, cfg *CompressionConfig) (*CompressingConnector, error) {
+	if cfg == nil {
+		cfg = &CompressionConfig{Codec: "none"}
+	}
+	reg, err := newCodecRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &CompressingConnector{Connector: conn, codecs: reg}, nil
+}
+
+func (c *CompressingConnector) Set(ctx context.Context, partitionKey, rangeKey, value string) error {
+	encoded, err := c.codecs.encode([]byte(value))
+	if err != nil {
+		return err
+	}
+	return c.Connector.Set(ctx, partitionKey, rangeKey, string(encoded))
+}
+
+func (c *CompressingConnector) Get(ctx context.Context, index, partitionKey, rangeKey string) (string, error) {
+	raw, err := c.Connector.Get(ctx, index, partitionKey, rangeKey)
+	if err != nil || raw == "" {
+		return raw, err
+	}
+	if isLegacyPayload(raw) {
+		return raw, nil
+	}
+	decoded, err := c.codecs.decode([]byte(raw))
+	if err != nil {
+		// Most likely cause is a rotated zstd dictionary: this entry was
+		// compressed with one the current codecRegistry no longer has. That
+		// must not surface as a failed RPC - treat it like any other cache
+		// miss so the caller re-fetches and re-caches under the current codec.
+		return "", nil
+	}
+	return string(decoded), nil
+}
+
+// isLegacyPayload detects values written before this codec header existed:
+// those are always plain JSON (an object, array, string, bool, null, or
+// number), whereas a compressed payload's first byte is a CodecID that never
+// collides with a valid JSON leading byte.
+func isLegacyPayload(raw string) bool {
+	trimmed := bytes.TrimSpace([]byte(raw))
+	if len(trimmed) == 0 {
+		return true
+	}
+	switch trimmed[0] {
+	case '{', '[', '"', 't', 'f', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCodecRegistryRoundTrip(t *testing.T) {
+	for _, codecName := range []string{"none", "snappy", "zstd"} {
+		t.Run(codecName, func(t *testing.T) {
+			reg, err := newCodecRegistry(&CompressionConfig{Codec: codecName})
+			if err != nil {
+				t.Fatalf("newCodecRegistry: %v", err)
+			}
+
+			src := []byte(`{"hello":"world"}`)
+			encoded, err := reg.encode(src)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			decoded, err := reg.decode(encoded)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if string(decoded) != string(src) {
+				t.Fatalf("decode() = %q, want %q", decoded, src)
+			}
+		})
+	}
+}
+
+func TestIsLegacyPayload(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{`{"a":1}`, true},
+		{`[1,2,3]`, true},
+		{`"a string"`, true},
+		{"null", true},
+		{"true", true},
+		{"123", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := isLegacyPayload(tt.raw); got != tt.want {
+			t.Errorf("isLegacyPayload(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestCompressingConnectorGetTreatsDecodeFailureAsMiss(t *testing.T) {
+	mock := NewMockConnector()
+	// A header claiming the zstd codec, followed by bytes that are not a
+	// valid zstd frame (as if written under a dictionary we no longer have).
+	raw := string(encodeHeader(payloadHeader{CodecID: CodecZstd})) + "not-a-valid-zstd-frame"
+	mock.On("Get", context.Background(), "idx", "pk", "rk").Return(raw, nil)
+
+	conn, err := NewCompressingConnector(mock, &CompressionConfig{Codec: "zstd"})
+	if err != nil {
+		t.Fatalf("NewCompressingConnector: %v", err)
+	}
+
+	got, err := conn.Get(context.Background(), "idx", "pk", "rk")
+	if err != nil {
+		t.Fatalf("expected a decode failure to be reported as a miss, not an error: %v", err)
+	}
+	if got != "" {
+		t.Fatalf("expected empty result on decode failure, got %q", got)
+	}
+}
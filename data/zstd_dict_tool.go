@@ -0,0 +1,36 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// TrainZstdDictionary shells out to the `zstd` CLI to train a shared
+// dictionary from sample files (e.g. captured eth_getBlockByNumber/eth_getLogs
+// responses for a network) and writes it to outputPath. klauspost/compress
+// has no dictionary trainer of its own, so this wraps the reference
+// implementation the way the zstd project recommends for one-off training.
+//
+// The resulting file can be set as ConnectorConfig.Compression.ZstdDictPath
+// to have NewCompressingConnector load it for that network.
+func TrainZstdDictionary(outputPath string, maxDictSize int, sampleFiles []string) error {
+	if len(sampleFiles) == 0 {
+		return fmt.Errorf("zstd dictionary training needs at least one sample file")
+	}
+
+	args := append([]string{
+		"--train",
+		fmt.Sprintf("--maxdict=%d", maxDictSize),
+		"-o", outputPath,
+	}, sampleFiles...)
+
+	cmd := exec.Command("zstd", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("training zstd dictionary: %w", err)
+	}
+
+	return nil
+}
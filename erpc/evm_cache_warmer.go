@@ -0,0 +1,294 @@
+package erpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/erpc/erpc/common"
+	"github.com/rs/zerolog"
+)
+
+// supportedWarmMethods is the set of methods warmRequestForMethod/warmBlock
+// know how to warm. CacheWarmerConfig.Methods is validated against this at
+// construction time so a typo'd or unsupported method fails loudly at
+// startup instead of silently wedging warmUpTo's loop the first time it's
+// reached for a block.
+var supportedWarmMethods = map[string]bool{
+	"eth_getBlockByNumber":      true,
+	"eth_getLogs":               true,
+	"eth_getTransactionReceipt": true,
+}
+
+// CacheWarmerConfig configures the background warmer for a single network: it
+// specifies which methods to pre-populate and how far behind head to stay so
+// every warmed block is guaranteed to already be finalized.
+type CacheWarmerConfig struct {
+	// Enabled toggles warming for the network this warmer is attached to.
+	Enabled bool
+	// Methods to warm. Currently supported: "eth_getBlockByNumber", "eth_getLogs",
+	// "eth_getTransactionReceipt".
+	Methods []string
+	// LagBlocks is how many blocks behind the trusted upstream's head to warm,
+	// so the warmed block is guaranteed finalized by the time it's cached.
+	LagBlocks int64
+	// PollInterval is used when the trusted upstream does not support eth_subscribe.
+	PollInterval time.Duration
+}
+
+// TrustedUpstream is the minimal surface the cache warmer needs from an
+// upstream client: enough to poll/subscribe for heads and replay canonical
+// JSON-RPC calls for the blocks it wants to warm.
+type TrustedUpstream interface {
+	EvmSubscribeNewHeads(ctx context.Context) (<-chan int64, error)
+	EvmGetBlockNumber(ctx context.Context) (int64, error)
+	Forward(ctx context.Context, req *common.NormalizedRequest) (*common.NormalizedResponse, error)
+}
+
+// EvmCacheWarmer mirrors finalized state for a network from a trusted
+// upstream into the cache ahead of time, so the first real request for a
+// historical block is already a cache hit.
+type EvmCacheWarmer struct {
+	cfg      *CacheWarmerConfig
+	cache    *EvmJsonRpcCache
+	network  *Network
+	upstream TrustedUpstream
+	logger   *zerolog.Logger
+
+	lastWarmed int64
+}
+
+// NewEvmCacheWarmer creates a warmer bound to a single network's cache and a
+// designated trusted upstream. It returns an error up front if cfg.Methods
+// names a method the warmer doesn't know how to warm, rather than letting
+// that surface later as a silent, permanent stall in warmUpTo.
+func NewEvmCacheWarmer(logger *zerolog.Logger, cfg *CacheWarmerConfig, cache *EvmJsonRpcCache, network *Network, upstream TrustedUpstream) (*EvmCacheWarmer, error) {
+	for _, method := range cfg.Methods {
+		if !supportedWarmMethods[method] {
+			return nil, fmt.Errorf("cache warmer does not know how to warm method %q", method)
+		}
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 6 * time.Second
+	}
+	return &EvmCacheWarmer{
+		cfg:      cfg,
+		cache:    cache,
+		network:  network,
+		upstream: upstream,
+		logger:   logger,
+	}, nil
+}
+
+// Start runs the warmer loop until ctx is canceled. It prefers eth_subscribe
+// newHeads on the trusted upstream and falls back to polling eth_blockNumber
+// when subscriptions aren't available.
+func (w *EvmCacheWarmer) Start(ctx context.Context) error {
+	if !w.cfg.Enabled {
+		return nil
+	}
+
+	heads, err := w.upstream.EvmSubscribeNewHeads(ctx)
+	if err != nil {
+		w.logger.Debug().Err(err).Msg("trusted upstream does not support eth_subscribe, falling back to polling")
+		return w.pollLoop(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case head, ok := <-heads:
+			if !ok {
+				return w.pollLoop(ctx)
+			}
+			w.warmUpTo(ctx, head-w.cfg.LagBlocks)
+		}
+	}
+}
+
+func (w *EvmCacheWarmer) pollLoop(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			head, err := w.upstream.EvmGetBlockNumber(ctx)
+			if err != nil {
+				w.logger.Warn().Err(err).Msg("failed to poll trusted upstream for head block")
+				continue
+			}
+			w.warmUpTo(ctx, head-w.cfg.LagBlocks)
+		}
+	}
+}
+
+// warmUpTo warms every block between the last warmed block and target,
+// inclusive, skipping if target has not advanced or is not yet finalized.
+func (w *EvmCacheWarmer) warmUpTo(ctx context.Context, target int64) {
+	if target <= w.lastWarmed {
+		return
+	}
+
+	for n := w.lastWarmed + 1; n <= target; n++ {
+		if fin, err := w.network.EvmIsBlockFinalized(n); err != nil || !fin {
+			break
+		}
+		if err := w.warmBlock(ctx, n); err != nil {
+			w.logger.Warn().Err(err).Int64("blockNumber", n).Msg("failed to warm cache for block")
+			break
+		}
+		w.lastWarmed = n
+	}
+}
+
+// warmBlock replays the configured warm methods for a single block number
+// against the trusted upstream and writes each response into the cache under
+// the exact key Get would use, via the cache's normal Set path.
+// A method may have nothing to warm for this particular block number (e.g.
+// eth_getLogs only warms once its whole bucket is complete); warmRequestForMethod
+// returns a nil request in that case and it's skipped rather than treated as an error.
+//
+// eth_getTransactionReceipt is keyed by tx hash, not block number, so it's
+// handled separately: the block is fetched once (and reused if
+// eth_getBlockByNumber is also configured) to enumerate its transaction
+// hashes, then a receipt is warmed for each one.
+func (w *EvmCacheWarmer) warmBlock(ctx context.Context, number int64) error {
+	var block *common.NormalizedResponse
+
+	for _, method := range w.cfg.Methods {
+		if method == "eth_getTransactionReceipt" {
+			var err error
+			if block == nil {
+				block, err = w.warmAndFetch(ctx, "eth_getBlockByNumber", number)
+				if err != nil {
+					return err
+				}
+			}
+			if err := w.warmReceiptsForBlock(ctx, number, block); err != nil {
+				return err
+			}
+			continue
+		}
+		if method == "eth_getBlockByNumber" && block != nil {
+			// already forwarded and cached above for receipt warming
+			continue
+		}
+
+		req, err := warmRequestForMethod(method, number)
+		if err != nil {
+			return err
+		}
+		if req == nil {
+			continue
+		}
+
+		resp, err := w.upstream.Forward(ctx, req)
+		if err != nil {
+			return fmt.Errorf("warming %s for block %d: %w", method, number, err)
+		}
+
+		if err := w.cache.Set(ctx, req, resp); err != nil {
+			return fmt.Errorf("caching warmed %s for block %d: %w", method, number, err)
+		}
+
+		if method == "eth_getBlockByNumber" {
+			block = resp
+		}
+	}
+
+	return nil
+}
+
+// warmAndFetch forwards and caches a warm request for method/number and
+// returns the response, so callers that need the block's contents (not just
+// its cache entry) don't have to forward it again.
+func (w *EvmCacheWarmer) warmAndFetch(ctx context.Context, method string, number int64) (*common.NormalizedResponse, error) {
+	req, err := warmRequestForMethod(method, number)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, nil
+	}
+
+	resp, err := w.upstream.Forward(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("warming %s for block %d: %w", method, number, err)
+	}
+	if err := w.cache.Set(ctx, req, resp); err != nil {
+		return nil, fmt.Errorf("caching warmed %s for block %d: %w", method, number, err)
+	}
+	return resp, nil
+}
+
+// warmReceiptsForBlock warms eth_getTransactionReceipt for every transaction
+// in block. block may be nil (warmAndFetch yielded nothing to warm), in
+// which case there's simply nothing to do.
+func (w *EvmCacheWarmer) warmReceiptsForBlock(ctx context.Context, number int64, block *common.NormalizedResponse) error {
+	if block == nil {
+		return nil
+	}
+	rpcResp, err := block.JsonRpcResponse()
+	if err != nil || rpcResp.Result == nil {
+		return err
+	}
+
+	var body struct {
+		Transactions []struct {
+			Hash string `json:"hash"`
+		} `json:"transactions"`
+	}
+	if err := sonic.Unmarshal(rpcResp.Result, &body); err != nil {
+		return fmt.Errorf("parsing block %d to enumerate transactions for receipt warming: %w", number, err)
+	}
+
+	for _, tx := range body.Transactions {
+		req, err := common.NewNormalizedRequestFromJsonRpc("eth_getTransactionReceipt", []interface{}{tx.Hash})
+		if err != nil {
+			return err
+		}
+		resp, err := w.upstream.Forward(ctx, req)
+		if err != nil {
+			return fmt.Errorf("warming eth_getTransactionReceipt for tx %s: %w", tx.Hash, err)
+		}
+		if err := w.cache.Set(ctx, req, resp); err != nil {
+			return fmt.Errorf("caching warmed eth_getTransactionReceipt for tx %s: %w", tx.Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// warmRequestForMethod builds the NormalizedRequest used to warm a given
+// method for a block number, matching the params shape the network's real
+// clients would send so the cache key generated on Set matches future Gets.
+// It returns a nil request (not an error) when there's nothing to warm for
+// this particular block number. eth_getTransactionReceipt is not handled
+// here since it's keyed by tx hash, not block number; see warmReceiptsForBlock.
+func warmRequestForMethod(method string, number int64) (*common.NormalizedRequest, error) {
+	blockNumHex := fmt.Sprintf("0x%x", number)
+
+	switch method {
+	case "eth_getBlockByNumber":
+		return common.NewNormalizedRequestFromJsonRpc(method, []interface{}{blockNumHex, true})
+	case "eth_getLogs":
+		// SetLogs only ever writes a whole, 128-block-aligned bucket, so a
+		// single-block filter here would warm nothing; only fire once number
+		// is the last block of its bucket, covering the bucket in full.
+		bucketEnd := bucketStart(number) + logsBucketSize - 1
+		if number != bucketEnd {
+			return nil, nil
+		}
+		return common.NewNormalizedRequestFromJsonRpc(method, []interface{}{map[string]interface{}{
+			"fromBlock": fmt.Sprintf("0x%x", bucketStart(number)),
+			"toBlock":   blockNumHex,
+		}})
+	default:
+		return nil, fmt.Errorf("cache warmer does not know how to build a request for method %q", method)
+	}
+}
@@ -0,0 +1,45 @@
+package erpc
+
+import "testing"
+
+func TestWarmRequestForMethodEthGetLogsOnlyOnBucketBoundary(t *testing.T) {
+	req, err := warmRequestForMethod("eth_getLogs", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req != nil {
+		t.Fatalf("expected no request for a mid-bucket block number, got one")
+	}
+
+	req, err = warmRequestForMethod("eth_getLogs", 127)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req == nil {
+		t.Fatalf("expected a request at the last block of a bucket")
+	}
+}
+
+func TestWarmRequestForMethodEthGetBlockByNumberAlwaysFires(t *testing.T) {
+	req, err := warmRequestForMethod("eth_getBlockByNumber", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req == nil {
+		t.Fatalf("expected a request for every block number")
+	}
+}
+
+func TestNewEvmCacheWarmerRejectsUnsupportedMethod(t *testing.T) {
+	cfg := &CacheWarmerConfig{Methods: []string{"eth_getBalance"}}
+	if _, err := NewEvmCacheWarmer(nil, cfg, nil, nil, nil); err == nil {
+		t.Fatalf("expected an error for a method the warmer doesn't know how to warm")
+	}
+}
+
+func TestNewEvmCacheWarmerAcceptsSupportedMethods(t *testing.T) {
+	cfg := &CacheWarmerConfig{Methods: []string{"eth_getBlockByNumber", "eth_getLogs", "eth_getTransactionReceipt"}}
+	if _, err := NewEvmCacheWarmer(nil, cfg, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error for supported methods: %v", err)
+	}
+}
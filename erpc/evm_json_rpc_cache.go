@@ -14,15 +14,70 @@ import (
 )
 
 type EvmJsonRpcCache struct {
-	conn    data.Connector
-	network *Network
-	logger  *zerolog.Logger
+	conn                 data.Connector
+	network              *Network
+	logger               *zerolog.Logger
+	methodPolicies       map[string]cacheMethodPolicy
+	reorgDetector        *EvmReorgDetector
+	reorgDetectorCfg     *ReorgDetectorConfig
+	cacheWarmer          *EvmCacheWarmer
+	cacheWarmerCfg       *CacheWarmerConfig
+	negativeCacheEnabled bool
+	negativeCacheQuorum  int
+	negativeCacheVotes   *negativeResultTracker
 }
 
 const (
 	JsonRpcCacheContext common.ContextKey = "jsonRpcCache"
 )
 
+// CachePolicyClass describes how a method's response may be cached, mirroring
+// the proxyd approach of only caching what's provably safe (immutable or
+// hash-addressed data) instead of gating everything on block finality.
+type CachePolicyClass string
+
+const (
+	// CachePolicyClassImmutable covers responses that never change once returned
+	// and carry no block reference at all, e.g. eth_chainId or net_version.
+	CachePolicyClassImmutable CachePolicyClass = "immutable"
+	// CachePolicyClassHashBased covers methods addressed by a block/tx hash, which
+	// uniquely identifies immutable data regardless of finality.
+	CachePolicyClassHashBased CachePolicyClass = "hash-based"
+	// CachePolicyClassFinalizedOnly is the default: only cache once the referenced
+	// block is finalized, to avoid serving reorg-able data.
+	CachePolicyClassFinalizedOnly CachePolicyClass = "finalized-only"
+	// CachePolicyClassRealtimeTTL covers fast-changing methods that are still safe
+	// to cache for a short, bounded TTL (e.g. eth_gasPrice, eth_blockNumber).
+	CachePolicyClassRealtimeTTL CachePolicyClass = "realtime-ttl"
+)
+
+type cacheMethodPolicy struct {
+	Class CachePolicyClass
+	TTL   time.Duration
+}
+
+// defaultCacheMethodPolicies is the built-in policy table for well-known methods.
+// Methods not listed here fall back to CachePolicyClassFinalizedOnly, which is
+// the historical (and safest) behavior.
+var defaultCacheMethodPolicies = map[string]cacheMethodPolicy{
+	"eth_chainId": {Class: CachePolicyClassImmutable},
+	"net_version": {Class: CachePolicyClassImmutable},
+
+	// eth_getCode can change if an account's code is redeployed via
+	// SELFDESTRUCT+re-create, so unlike eth_chainId/net_version it's only
+	// safe to cache once the requested block is finalized, not unconditionally.
+	"eth_getCode": {Class: CachePolicyClassFinalizedOnly},
+
+	"eth_getBlockByHash":                      {Class: CachePolicyClassHashBased},
+	"eth_getTransactionByHash":                {Class: CachePolicyClassHashBased},
+	"eth_getTransactionReceipt":               {Class: CachePolicyClassHashBased},
+	"eth_getTransactionByBlockHashAndIndex":   {Class: CachePolicyClassHashBased},
+	"eth_getTransactionByBlockNumberAndIndex": {Class: CachePolicyClassFinalizedOnly},
+
+	"eth_gasPrice":    {Class: CachePolicyClassRealtimeTTL, TTL: 3 * time.Second},
+	"eth_blockNumber": {Class: CachePolicyClassRealtimeTTL, TTL: 1 * time.Second},
+}
+
 func NewEvmJsonRpcCache(ctx context.Context, logger *zerolog.Logger, cfg *common.ConnectorConfig) (*EvmJsonRpcCache, error) {
 	logger.Info().Msg("initializing evm json rpc cache...")
 	err := populateDefaults(cfg)
@@ -35,11 +90,38 @@ func NewEvmJsonRpcCache(ctx context.Context, logger *zerolog.Logger, cfg *common
 		return nil, err
 	}
 
+	if cfg.Compression != nil {
+		c, err = data.NewCompressingConnector(c, cfg.Compression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// start from the built-in policy table, then let explicit per-method config
+	// override both the TTL and the cacheability class for a method
+	methodPolicies := make(map[string]cacheMethodPolicy, len(defaultCacheMethodPolicies))
+	for method, policy := range defaultCacheMethodPolicies {
+		methodPolicies[method] = policy
+	}
+
 	// set TTL method overrides
 	for _, cacheInfo := range cfg.Methods {
 		if err := c.SetTTL(cacheInfo.Method, cacheInfo.TTL); err != nil {
 			return nil, err
 		}
+		policy := methodPolicies[cacheInfo.Method]
+		if cacheInfo.Policy != "" {
+			policy.Class = CachePolicyClass(cacheInfo.Policy)
+		}
+		if cacheInfo.TTL != "" {
+			if ttl, err := time.ParseDuration(cacheInfo.TTL); err == nil {
+				policy.TTL = ttl
+				if policy.Class == "" {
+					policy.Class = CachePolicyClassRealtimeTTL
+				}
+			}
+		}
+		methodPolicies[cacheInfo.Method] = policy
 	}
 
 	// set non cacheable methods
@@ -52,18 +134,111 @@ func NewEvmJsonRpcCache(ctx context.Context, logger *zerolog.Logger, cfg *common
 
 	}
 
+	negativeCacheQuorum := 1
+	negativeCacheEnabled := false
+	negativeCacheVoteTTL := defaultNegativeVoteTTL
+	if cfg.NegativeCache != nil {
+		negativeCacheEnabled = cfg.NegativeCache.Enabled
+		if cfg.NegativeCache.Quorum > 0 {
+			negativeCacheQuorum = cfg.NegativeCache.Quorum
+		}
+		if cfg.NegativeCache.VoteTTL != "" {
+			if ttl, err := time.ParseDuration(cfg.NegativeCache.VoteTTL); err == nil {
+				negativeCacheVoteTTL = ttl
+			}
+		}
+	}
+
 	return &EvmJsonRpcCache{
-		conn:   c,
-		logger: logger,
+		conn:                 c,
+		logger:               logger,
+		methodPolicies:       methodPolicies,
+		reorgDetectorCfg:     cfg.ReorgDetector,
+		cacheWarmerCfg:       cfg.CacheWarmer,
+		negativeCacheEnabled: negativeCacheEnabled,
+		negativeCacheQuorum:  negativeCacheQuorum,
+		negativeCacheVotes:   newNegativeResultTrackerWithTTL(negativeCacheVoteTTL),
 	}, nil
 }
 
+// resolveCachePolicy returns the cacheability policy for a method, falling back
+// to CachePolicyClassFinalizedOnly when no explicit or built-in policy exists.
+func (c *EvmJsonRpcCache) resolveCachePolicy(method string) cacheMethodPolicy {
+	if policy, ok := c.methodPolicies[method]; ok && policy.Class != "" {
+		return policy
+	}
+	return cacheMethodPolicy{Class: CachePolicyClassFinalizedOnly}
+}
+
+// WithNetwork binds this cache to a specific network. It does not start the
+// reorg detector or cache warmer itself - both need a concrete source
+// (a subscription client for new heads, a trusted upstream to replay
+// requests against) that only the code constructing the network actually
+// has. Use ReorgDetectorConfig/CacheWarmerConfig to see whether each is
+// enabled, then construct and start them explicitly and attach the result
+// with WithReorgDetector/WithCacheWarmer.
 func (c *EvmJsonRpcCache) WithNetwork(network *Network) *EvmJsonRpcCache {
 	network.Logger.Debug().Msgf("creating EvmJsonRpcCache")
 	return &EvmJsonRpcCache{
-		logger:  c.logger,
-		conn:    c.conn,
-		network: network,
+		logger:               c.logger,
+		conn:                 c.conn,
+		network:              network,
+		methodPolicies:       c.methodPolicies,
+		reorgDetectorCfg:     c.reorgDetectorCfg,
+		cacheWarmerCfg:       c.cacheWarmerCfg,
+		negativeCacheEnabled: c.negativeCacheEnabled,
+		negativeCacheQuorum:  c.negativeCacheQuorum,
+		negativeCacheVotes:   c.negativeCacheVotes,
+	}
+}
+
+// ReorgDetectorConfig returns the reorg detector configuration for this
+// network, or nil if none was configured.
+func (c *EvmJsonRpcCache) ReorgDetectorConfig() *ReorgDetectorConfig {
+	return c.reorgDetectorCfg
+}
+
+// CacheWarmerConfig returns the cache warmer configuration for this network,
+// or nil if none was configured.
+func (c *EvmJsonRpcCache) CacheWarmerConfig() *CacheWarmerConfig {
+	return c.cacheWarmerCfg
+}
+
+// WithReorgDetector attaches a reorg detector so that hash-based tx/receipt
+// entries cached by this instance can be purged if their block is orphaned.
+// The caller is responsible for starting detector.Watch with a real
+// EvmNewHeadsSource once ReorgDetectorConfig().Enabled is true.
+func (c *EvmJsonRpcCache) WithReorgDetector(detector *EvmReorgDetector) *EvmJsonRpcCache {
+	return &EvmJsonRpcCache{
+		logger:               c.logger,
+		conn:                 c.conn,
+		network:              c.network,
+		negativeCacheEnabled: c.negativeCacheEnabled,
+		negativeCacheQuorum:  c.negativeCacheQuorum,
+		negativeCacheVotes:   c.negativeCacheVotes,
+		methodPolicies:       c.methodPolicies,
+		reorgDetectorCfg:     c.reorgDetectorCfg,
+		cacheWarmerCfg:       c.cacheWarmerCfg,
+		reorgDetector:        detector,
+	}
+}
+
+// WithCacheWarmer attaches a cache warmer that's already been constructed
+// (with a real TrustedUpstream) and started by the caller once
+// CacheWarmerConfig().Enabled is true.
+func (c *EvmJsonRpcCache) WithCacheWarmer(warmer *EvmCacheWarmer) *EvmJsonRpcCache {
+	return &EvmJsonRpcCache{
+		logger:               c.logger,
+		conn:                 c.conn,
+		network:              c.network,
+		negativeCacheEnabled: c.negativeCacheEnabled,
+		negativeCacheQuorum:  c.negativeCacheQuorum,
+		negativeCacheVotes:   c.negativeCacheVotes,
+		methodPolicies:       c.methodPolicies,
+		reorgDetectorCfg:     c.reorgDetectorCfg,
+		cacheWarmerCfg:       c.cacheWarmerCfg,
+		reorgDetector:        c.reorgDetector,
+		cacheWarmer:          warmer,
 	}
 }
 
@@ -78,16 +253,24 @@ func (c *EvmJsonRpcCache) Get(ctx context.Context, req *common.NormalizedRequest
 		return nil, nil
 	}
 
-	hasTTL := c.conn.HasTTL(rpcReq.Method)
+	// eth_getLogs has its own range-bucketed storage instead of the generic
+	// whole-request cache below; a partial hit still serves the cached
+	// prefix and live-fetches only the residual tail (see getLogsWithLiveTail).
+	if rpcReq.Method == "eth_getLogs" {
+		return c.getLogsWithLiveTail(ctx, req, rpcReq)
+	}
+
+	policy := c.resolveCachePolicy(rpcReq.Method)
+	hasTTL := policy.Class == CachePolicyClassRealtimeTTL || c.conn.HasTTL(rpcReq.Method)
 
 	blockRef, blockNumber, err := common.ExtractEvmBlockReferenceFromRequest(rpcReq)
 	if err != nil {
 		return nil, err
 	}
-	if blockRef == "" && blockNumber == 0 && !hasTTL {
+	if blockRef == "" && blockNumber == 0 && !hasTTL && policy.Class != CachePolicyClassImmutable {
 		return nil, nil
 	}
-	if blockNumber != 0 {
+	if blockNumber != 0 && policy.Class == CachePolicyClassFinalizedOnly {
 		s, err := c.shouldCacheForBlock(blockNumber)
 		if err == nil && !s {
 			return nil, nil
@@ -109,6 +292,23 @@ func (c *EvmJsonRpcCache) Get(ctx context.Context, req *common.NormalizedRequest
 		return nil, err
 	}
 
+	if resultString == "" {
+		return nil, nil
+	}
+
+	if entry, ok := decodeNegativeCacheEntry(resultString); ok {
+		jrr := &common.JsonRpcResponse{
+			JSONRPC: rpcReq.JSONRPC,
+			ID:      rpcReq.ID,
+			Error:   nil,
+			Result:  entry.Value,
+		}
+		return common.NewNormalizedResponse().
+			WithRequest(req).
+			WithFromCache(true).
+			WithJsonRpcResponse(jrr), nil
+	}
+
 	if resultString == `""` || resultString == "null" || resultString == "[]" || resultString == "{}" {
 		return nil, nil
 	}
@@ -137,6 +337,11 @@ func (c *EvmJsonRpcCache) Set(ctx context.Context, req *common.NormalizedRequest
 		return err
 	}
 
+	// eth_getLogs is stored per finalized block-range bucket; see SetLogs.
+	if rpcReq.Method == "eth_getLogs" {
+		return c.SetLogs(ctx, req, resp)
+	}
+
 	// Skip cache for ignored methods
 	if c.conn.IsMethodIgnored(rpcReq.Method) {
 		return nil
@@ -144,7 +349,9 @@ func (c *EvmJsonRpcCache) Set(ctx context.Context, req *common.NormalizedRequest
 
 	lg := c.logger.With().Str("networkId", req.NetworkId()).Str("method", rpcReq.Method).Logger()
 
-	shouldCache, err := shouldCache(lg, req, resp, rpcReq, rpcResp)
+	policy := c.resolveCachePolicy(rpcReq.Method)
+
+	shouldCache, err := c.shouldCache(lg, req, resp, rpcReq, rpcResp, policy)
 	if !shouldCache || err != nil {
 		return err
 	}
@@ -154,9 +361,9 @@ func (c *EvmJsonRpcCache) Set(ctx context.Context, req *common.NormalizedRequest
 		return err
 	}
 
-	hasTTL := c.conn.HasTTL(rpcReq.Method)
+	hasTTL := policy.Class == CachePolicyClassRealtimeTTL || c.conn.HasTTL(rpcReq.Method)
 
-	if blockRef == "" && blockNumber == 0 && !hasTTL {
+	if blockRef == "" && blockNumber == 0 && !hasTTL && policy.Class != CachePolicyClassImmutable {
 		// Do not cache if we can't resolve a block reference (e.g. latest block requests)
 		lg.Debug().
 			Str("blockRef", blockRef).
@@ -165,27 +372,16 @@ func (c *EvmJsonRpcCache) Set(ctx context.Context, req *common.NormalizedRequest
 		return nil
 	}
 
-	if !hasTTL {
-		if blockRef == "" && blockNumber == 0 {
-			// Do not cache if we can't resolve a block reference (e.g. latest block requests)
+	if policy.Class == CachePolicyClassFinalizedOnly && !hasTTL && blockNumber > 0 {
+		s, e := c.shouldCacheForBlock(blockNumber)
+		if !s || e != nil {
 			lg.Debug().
+				Err(e).
 				Str("blockRef", blockRef).
 				Int64("blockNumber", blockNumber).
-				Msg("will not cache the response because it has no block reference or block number")
-			return nil
-		}
-
-		if blockNumber > 0 {
-			s, e := c.shouldCacheForBlock(blockNumber)
-			if !s || e != nil {
-				lg.Debug().
-					Err(e).
-					Str("blockRef", blockRef).
-					Int64("blockNumber", blockNumber).
-					Interface("result", rpcResp.Result).
-					Msg("will not cache the response because block is not finalized")
-				return e
-			}
+				Interface("result", rpcResp.Result).
+				Msg("will not cache the response because block is not finalized")
+			return e
 		}
 	}
 
@@ -207,56 +403,45 @@ func (c *EvmJsonRpcCache) Set(ctx context.Context, req *common.NormalizedRequest
 		return err
 	}
 
-	ctx, cancel := context.WithTimeoutCause(ctx, 5*time.Second, errors.New("evm json-rpc cache driver timeout during set"))
+	if resp.IsResultEmptyish() {
+		// Store negative results behind a sentinel wrapper so Get can tell a
+		// confirmed-empty hit apart from never having cached anything here.
+		resultBytes, err = sonic.Marshal(negativeCacheEntry{Negative: true, Value: json.RawMessage(resultBytes)})
+		if err != nil {
+			return err
+		}
+	}
+
+	setCtx, cancel := context.WithTimeoutCause(ctx, 5*time.Second, errors.New("evm json-rpc cache driver timeout during set"))
 	defer cancel()
-	return c.conn.Set(ctx, pk, rk, string(resultBytes))
+	return c.conn.Set(setCtx, pk, rk, string(resultBytes))
 }
 
-func shouldCache(
+// shouldCache decides whether a response is safe to write into the cache.
+// Non-results (errors, nil responses) are always skipped; an empty/negative
+// result goes through the dedicated negative-cache gating in
+// shouldCacheNegativeResult instead of being dropped outright.
+func (c *EvmJsonRpcCache) shouldCache(
 	lg zerolog.Logger,
 	req *common.NormalizedRequest,
 	resp *common.NormalizedResponse,
 	rpcReq *common.JsonRpcRequest,
 	rpcResp *common.JsonRpcResponse,
+	policy cacheMethodPolicy,
 ) (bool, error) {
-	if resp == nil ||
-		resp.IsObjectNull() ||
-		resp.IsResultEmptyish() ||
-		rpcResp == nil ||
-		rpcResp.Result == nil ||
-		rpcResp.Error != nil {
-		ups := resp.Upstream()
-		if ups != nil {
-			upsCfg := ups.Config()
-			if upsCfg.Evm != nil {
-				if upsCfg.Evm.Syncing != nil && !*upsCfg.Evm.Syncing {
-					blkNum, err := req.EvmBlockNumber()
-					if err != nil && blkNum > 0 {
-						ntw := req.Network()
-						if ntw != nil {
-							if fin, err := ntw.EvmIsBlockFinalized(blkNum); err != nil && fin {
-								return fin, nil
-							}
-						}
-					}
-				}
-			}
-		}
-
-		lg.Debug().Msg("skip caching because it has no result or has error and we cannot determine finality and sync-state")
+	if resp == nil || rpcResp == nil || rpcResp.Result == nil || rpcResp.Error != nil {
+		lg.Debug().Msg("skip caching because there is no result or it has an error")
 		return false, nil
 	}
 
-	switch rpcReq.Method {
-	case "eth_getTransactionByHash",
-		"eth_getTransactionReceipt",
-		"eth_getTransactionByBlockHashAndIndex",
-		"eth_getTransactionByBlockNumberAndIndex":
+	if resp.IsObjectNull() || resp.IsResultEmptyish() {
+		return c.shouldCacheNegativeResult(lg, req, resp)
+	}
 
+	if policy.Class == CachePolicyClassHashBased {
 		// When transactions are not yet included in a block blockNumber/blockHash is still unknown
 		// For these transaction for now we will not cache the response, but still must be returned
 		// to the client because they might be intentionally looking for pending txs.
-		// Is there a reliable way to cache these and bust in-case of a reorg?
 		blkRef, blkNum, err := common.ExtractEvmBlockReferenceFromResponse(rpcReq, rpcResp)
 		if err != nil {
 			lg.Error().Err(err).Msg("skip caching because error extracting block reference from response")
@@ -273,10 +458,70 @@ func shouldCache(
 	return true, nil
 }
 
+// shouldCacheNegativeResult decides whether an empty/negative response
+// ([], null, 0x, etc.) may be promoted into the cache. It requires the
+// responding upstream to report !syncing AND the requested block to be
+// finalized, then requires a quorum of distinct upstreams to have reported
+// the same empty result before writing it, so a single lagging node can't
+// poison the cache for everyone else.
+func (c *EvmJsonRpcCache) shouldCacheNegativeResult(lg zerolog.Logger, req *common.NormalizedRequest, resp *common.NormalizedResponse) (bool, error) {
+	if !c.negativeCacheEnabled {
+		lg.Debug().Msg("skip caching negative result because negative-result caching is disabled")
+		return false, nil
+	}
+
+	ups := resp.Upstream()
+	if ups == nil {
+		lg.Debug().Msg("skip caching negative result because response has no upstream")
+		return false, nil
+	}
+	upsCfg := ups.Config()
+	if upsCfg.Evm == nil || upsCfg.Evm.Syncing == nil || *upsCfg.Evm.Syncing {
+		lg.Debug().Msg("skip caching negative result because upstream sync-state is unknown or syncing")
+		return false, nil
+	}
+
+	blkNum, err := req.EvmBlockNumber()
+	if err != nil || blkNum <= 0 {
+		lg.Debug().Msg("skip caching negative result because request has no resolvable block number")
+		return false, nil
+	}
+
+	ntw := req.Network()
+	if ntw == nil {
+		lg.Debug().Msg("skip caching negative result because request has no network")
+		return false, nil
+	}
+	fin, err := ntw.EvmIsBlockFinalized(blkNum)
+	if err != nil || !fin {
+		lg.Debug().Int64("blockNumber", blkNum).Msg("skip caching negative result because block is not finalized")
+		return false, nil
+	}
+
+	cacheKey, err := req.CacheHash()
+	if err != nil {
+		return false, err
+	}
+	// negativeCacheVotes is shared across every network's WithNetwork copy of
+	// this cache, so the quorum key must include the network - otherwise an
+	// identical method+params request on an unrelated chain would count
+	// toward (and could satisfy) this network's quorum.
+	quorumKey := fmt.Sprintf("%s:%s", req.NetworkId(), cacheKey)
+
+	if reached := c.negativeCacheVotes.recordAndCheckQuorum(quorumKey, upsCfg.Id, c.negativeCacheQuorum); !reached {
+		lg.Debug().Int("quorum", c.negativeCacheQuorum).Msg("holding back negative result until quorum of upstreams agree")
+		return false, nil
+	}
+
+	return true, nil
+}
+
 func (c *EvmJsonRpcCache) DeleteByGroupKey(ctx context.Context, groupKeys ...string) error {
 	for _, groupKey := range groupKeys {
-		err := c.conn.Delete(ctx, data.ConnectorMainIndex, groupKey, "")
-		if err != nil {
+		if err := c.conn.Delete(ctx, data.ConnectorMainIndex, groupKey, ""); err != nil {
+			return err
+		}
+		if err := c.conn.Delete(ctx, data.ConnectorReverseIndex, groupKey, ""); err != nil {
 			return err
 		}
 	}
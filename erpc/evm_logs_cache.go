@@ -0,0 +1,418 @@
+package erpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/erpc/erpc/common"
+	"github.com/erpc/erpc/data"
+)
+
+// logsBucketSize is the number of blocks per eth_getLogs cache bucket. Buckets
+// are only ever written once every block in them is finalized and fully
+// covered by the response being written; on read, a bucket that a request
+// range only partly overlaps is still served, filtered down to that range.
+const logsBucketSize = int64(128)
+
+// logsFilter is the decomposed form of an eth_getLogs request: the block
+// range being asked for, plus the address/topic filter that range is scoped
+// to. Two requests with the same addresses/topics but different ranges share
+// the same bucket entries.
+type logsFilter struct {
+	FromBlock int64
+	ToBlock   int64
+	Addresses []string
+	Topics    string // JSON-encoded topics array, used only for hashing
+}
+
+// Hash identifies the address/topic filter (independent of block range) that
+// a bucket of cached logs was stored under.
+func (f *logsFilter) Hash() string {
+	h := sha256.New()
+	for _, a := range f.Addresses {
+		h.Write([]byte(a))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(f.Topics))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// parseEthGetLogsFilter decomposes an eth_getLogs request's filter object
+// into (fromBlock, toBlock, address set, topic set). It returns a nil filter
+// (not an error) when fromBlock/toBlock aren't concrete block numbers (e.g.
+// "latest"), since those ranges can't be bucketed.
+func parseEthGetLogsFilter(rpcReq *common.JsonRpcRequest) (*logsFilter, error) {
+	params, ok := rpcReq.Params.([]interface{})
+	if !ok || len(params) == 0 {
+		return nil, fmt.Errorf("eth_getLogs expects a filter object as its first param")
+	}
+	raw, ok := params[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("eth_getLogs filter param is not an object")
+	}
+
+	from, ok := parseBlockTag(raw["fromBlock"])
+	if !ok {
+		return nil, nil
+	}
+	to, ok := parseBlockTag(raw["toBlock"])
+	if !ok {
+		return nil, nil
+	}
+
+	f := &logsFilter{FromBlock: from, ToBlock: to}
+
+	switch addr := raw["address"].(type) {
+	case string:
+		f.Addresses = []string{strings.ToLower(addr)}
+	case []interface{}:
+		for _, a := range addr {
+			if s, ok := a.(string); ok {
+				f.Addresses = append(f.Addresses, strings.ToLower(s))
+			}
+		}
+	}
+	sort.Strings(f.Addresses)
+
+	if topics, ok := raw["topics"]; ok {
+		if enc, err := json.Marshal(topics); err == nil {
+			f.Topics = string(enc)
+		}
+	}
+
+	return f, nil
+}
+
+// parseBlockTag returns the concrete block number for a filter's
+// fromBlock/toBlock value, and false if it's a tag like "latest"/"pending"
+// that can't be resolved to a fixed bucket.
+func parseBlockTag(v interface{}) (int64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	if !strings.HasPrefix(s, "0x") {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimPrefix(s, "0x"), 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func bucketStart(blockNumber int64) int64 {
+	return (blockNumber / logsBucketSize) * logsBucketSize
+}
+
+// bucketsForRange returns the start block of every bucket fully contained
+// within [from, to]; partial buckets at either edge are left for a live fetch.
+// This is used on the write side (SetLogs), where only a bucket whose entire
+// span was actually covered by the response being written is safe to store.
+func bucketsForRange(from, to int64) []int64 {
+	var buckets []int64
+	for b := bucketStart(from); b <= to; b += logsBucketSize {
+		if b >= from && b+logsBucketSize-1 <= to {
+			buckets = append(buckets, b)
+		}
+	}
+	return buckets
+}
+
+// bucketsTouchedByRange returns the start block of every bucket that
+// overlaps [from, to] at all, including the (possibly partial) bucket at
+// either edge. Unlike bucketsForRange, this is used on the read side: a
+// bucket cached in full is usable to answer a request that only asks for
+// part of it, so alignment to the request's own fromBlock/toBlock doesn't
+// matter here - GetLogs filters each bucket's logs down to [from, to] itself.
+func bucketsTouchedByRange(from, to int64) []int64 {
+	var buckets []int64
+	for b := bucketStart(from); b <= to; b += logsBucketSize {
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func logsBucketGroupKey(networkId string, bucket int64, filterHash string) string {
+	return fmt.Sprintf("%s:logsbucket:%d:%s", networkId, bucket, filterHash)
+}
+
+// GetLogs serves as much of an eth_getLogs request as possible from per-bucket
+// cache. It returns the stitched logs for the finalized buckets it could
+// serve (in block/logIndex order, since buckets are appended in ascending
+// order and each bucket's logs were stored in response order) plus the
+// [residualFrom, residualTo] sub-range the caller must still fetch live and
+// merge in. served is nil when nothing could be served from cache, in which
+// case residualFrom/residualTo cover the entire original request (or are both
+// 0 when fromBlock/toBlock aren't concrete block numbers).
+func (c *EvmJsonRpcCache) GetLogs(ctx context.Context, req *common.NormalizedRequest) (served []json.RawMessage, residualFrom int64, residualTo int64, err error) {
+	rpcReq, err := req.JsonRpcRequest()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	filter, err := parseEthGetLogsFilter(rpcReq)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if filter == nil {
+		return nil, 0, 0, nil
+	}
+	residualFrom, residualTo = filter.FromBlock, filter.ToBlock
+
+	// Seeded non-nil so a bucket that's cached and finalized but legitimately
+	// matches zero logs still leaves served non-nil; served stays nil only via
+	// the explicit early return below, which is what distinguishes "nothing
+	// cached for this range" from "cached, and it's just empty".
+	served = []json.RawMessage{}
+
+	hash := filter.Hash()
+	cachedThrough := filter.FromBlock - 1
+	// The first bucket we can possibly serve from is the one fromBlock falls
+	// in, even though that bucket usually starts before fromBlock; we slice
+	// off the part before fromBlock below. Comparing against this (rather
+	// than the literal fromBlock) is what lets a non-bucket-aligned request
+	// still hit a fully cached, 128-aligned bucket.
+	expectedBucket := bucketStart(filter.FromBlock)
+
+	for _, bucket := range bucketsTouchedByRange(filter.FromBlock, filter.ToBlock) {
+		if bucket != expectedBucket {
+			// a gap means the previous bucket wasn't finalized/cached yet; stop here
+			break
+		}
+		bucketEnd := bucket + logsBucketSize - 1
+		if fin, ferr := c.network.EvmIsBlockFinalized(bucketEnd); ferr != nil || !fin {
+			break
+		}
+
+		raw, gerr := c.conn.Get(ctx, data.ConnectorMainIndex, logsBucketGroupKey(req.NetworkId(), bucket, hash), "")
+		if gerr != nil || raw == "" {
+			break
+		}
+		var bucketLogs []json.RawMessage
+		if jerr := sonic.Unmarshal([]byte(raw), &bucketLogs); jerr != nil {
+			break
+		}
+
+		for _, l := range bucketLogs {
+			n, lerr := extractLogBlockNumber(l)
+			if lerr != nil || n < filter.FromBlock || n > filter.ToBlock {
+				continue
+			}
+			served = append(served, l)
+		}
+		cachedThrough = min64(bucketEnd, filter.ToBlock)
+		expectedBucket = bucket + logsBucketSize
+
+		if bucketEnd >= filter.ToBlock {
+			break
+		}
+	}
+
+	if cachedThrough < filter.FromBlock {
+		return nil, filter.FromBlock, filter.ToBlock, nil
+	}
+
+	return served, cachedThrough + 1, filter.ToBlock, nil
+}
+
+// getLogsWithLiveTail serves an eth_getLogs request by combining whatever
+// GetLogs could answer from bucket cache with a live upstream fetch of the
+// residual range, so a partial cache hit (the common case - recent blocks
+// are rarely finalized yet) still avoids re-fetching the cached prefix.
+// It returns (nil, nil) when nothing at all could be served from cache,
+// leaving the request to fall through to the network's normal live-fetch path.
+func (c *EvmJsonRpcCache) getLogsWithLiveTail(ctx context.Context, req *common.NormalizedRequest, rpcReq *common.JsonRpcRequest) (*common.NormalizedResponse, error) {
+	served, residualFrom, residualTo, err := c.GetLogs(ctx, req)
+	if err != nil || served == nil {
+		return nil, err
+	}
+
+	logs := served
+	fullyCached := residualFrom > residualTo
+	if !fullyCached {
+		tailReq, rerr := buildLogsRangeRequest(rpcReq, residualFrom, residualTo)
+		if rerr != nil {
+			return nil, rerr
+		}
+		tailResp, ferr := c.network.Forward(ctx, tailReq)
+		if ferr != nil {
+			return nil, ferr
+		}
+		tailRpcResp, rerr := tailResp.JsonRpcResponse()
+		if rerr != nil {
+			return nil, rerr
+		}
+		if tailRpcResp.Error != nil || tailRpcResp.Result == nil {
+			return nil, nil
+		}
+		var tailLogs []json.RawMessage
+		if uerr := sonic.Unmarshal(tailRpcResp.Result, &tailLogs); uerr != nil {
+			return nil, uerr
+		}
+		logs = append(logs, tailLogs...)
+		sortLogsByBlockAndIndex(logs)
+
+		// Cache the freshly-fetched tail under its own bucket(s), if any of it
+		// is finalized by now, so a later request doesn't have to re-fetch it.
+		if serr := c.SetLogs(ctx, tailReq, tailResp); serr != nil {
+			c.logger.Warn().Err(serr).Msg("failed to cache live-fetched tail of a partially cached eth_getLogs response")
+		}
+	}
+
+	resultBytes, err := sonic.Marshal(logs)
+	if err != nil {
+		return nil, err
+	}
+
+	jrr := &common.JsonRpcResponse{
+		JSONRPC: rpcReq.JSONRPC,
+		ID:      rpcReq.ID,
+		Error:   nil,
+		Result:  json.RawMessage(resultBytes),
+	}
+
+	return common.NewNormalizedResponse().
+		WithRequest(req).
+		WithFromCache(fullyCached).
+		WithJsonRpcResponse(jrr), nil
+}
+
+// buildLogsRangeRequest clones an eth_getLogs request's filter with a
+// different [from, to] block range, keeping its original address/topics
+// untouched so the live tail fetch matches what the caller actually asked for.
+func buildLogsRangeRequest(rpcReq *common.JsonRpcRequest, from, to int64) (*common.NormalizedRequest, error) {
+	params, ok := rpcReq.Params.([]interface{})
+	if !ok || len(params) == 0 {
+		return nil, fmt.Errorf("eth_getLogs request has no filter param")
+	}
+	orig, ok := params[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("eth_getLogs filter param is not an object")
+	}
+
+	filter := make(map[string]interface{}, len(orig))
+	for k, v := range orig {
+		filter[k] = v
+	}
+	filter["fromBlock"] = fmt.Sprintf("0x%x", from)
+	filter["toBlock"] = fmt.Sprintf("0x%x", to)
+
+	return common.NewNormalizedRequestFromJsonRpc(rpcReq.Method, []interface{}{filter})
+}
+
+// sortLogsByBlockAndIndex restores ascending (blockNumber, logIndex) order
+// after a cached prefix and a live-fetched tail have been appended together.
+func sortLogsByBlockAndIndex(logs []json.RawMessage) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		bi, ii := logSortKey(logs[i])
+		bj, ij := logSortKey(logs[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return ii < ij
+	})
+}
+
+func logSortKey(raw json.RawMessage) (blockNumber int64, logIndex int64) {
+	var l struct {
+		BlockNumber string `json:"blockNumber"`
+		LogIndex    string `json:"logIndex"`
+	}
+	_ = sonic.Unmarshal(raw, &l)
+	blockNumber, _ = parseBlockTag(l.BlockNumber)
+	logIndex, _ = parseBlockTag(l.LogIndex)
+	return blockNumber, logIndex
+}
+
+// SetLogs writes an eth_getLogs response into per-bucket cache entries, one
+// per whole finalized block-range bucket the response covers. The partial
+// bucket at the head of the chain (the live tail) is never written.
+func (c *EvmJsonRpcCache) SetLogs(ctx context.Context, req *common.NormalizedRequest, resp *common.NormalizedResponse) error {
+	rpcReq, err := req.JsonRpcRequest()
+	if err != nil {
+		return err
+	}
+	rpcResp, err := resp.JsonRpcResponse()
+	if err != nil {
+		return err
+	}
+	if rpcResp.Result == nil || rpcResp.Error != nil {
+		return nil
+	}
+
+	filter, err := parseEthGetLogsFilter(rpcReq)
+	if err != nil || filter == nil {
+		return err
+	}
+
+	var logs []json.RawMessage
+	if err := sonic.Unmarshal(rpcResp.Result, &logs); err != nil {
+		return err
+	}
+
+	byBucket := make(map[int64][]json.RawMessage)
+	for _, l := range logs {
+		blockNumber, err := extractLogBlockNumber(l)
+		if err != nil {
+			continue
+		}
+		b := bucketStart(blockNumber)
+		byBucket[b] = append(byBucket[b], l)
+	}
+
+	hash := filter.Hash()
+	for _, bucket := range bucketsForRange(filter.FromBlock, filter.ToBlock) {
+		bucketEnd := bucket + logsBucketSize - 1
+		if fin, ferr := c.network.EvmIsBlockFinalized(bucketEnd); ferr != nil || !fin {
+			continue
+		}
+
+		bucketLogs := byBucket[bucket]
+		if bucketLogs == nil {
+			bucketLogs = []json.RawMessage{}
+		}
+		encoded, merr := sonic.Marshal(bucketLogs)
+		if merr != nil {
+			return merr
+		}
+		if err := c.conn.Set(ctx, logsBucketGroupKey(req.NetworkId(), bucket, hash), "", string(encoded)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractLogBlockNumber reads the blockNumber field off a single raw log
+// entry so SetLogs can sort it into the right bucket.
+func extractLogBlockNumber(raw json.RawMessage) (int64, error) {
+	var l struct {
+		BlockNumber string `json:"blockNumber"`
+	}
+	if err := sonic.Unmarshal(raw, &l); err != nil {
+		return 0, err
+	}
+	n, ok := parseBlockTag(l.BlockNumber)
+	if !ok {
+		return 0, fmt.Errorf("log entry has no parseable blockNumber: %q", l.BlockNumber)
+	}
+	return n, nil
+}
@@ -0,0 +1,55 @@
+package erpc
+
+import "testing"
+
+func TestBucketsForRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int64
+		want     []int64
+	}{
+		{"aligned single bucket", 0, 127, []int64{0}},
+		{"aligned two buckets", 0, 255, []int64{0, 128}},
+		{"unaligned edges exclude both partials", 50, 300, []int64{128}},
+		{"range shorter than one bucket", 10, 20, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bucketsForRange(tt.from, tt.to)
+			if !int64SlicesEqual(got, tt.want) {
+				t.Fatalf("bucketsForRange(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketsTouchedByRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int64
+		want     []int64
+	}{
+		{"unaligned fromBlock still touches its own bucket", 50, 300, []int64{0, 128, 256}},
+		{"aligned range", 0, 255, []int64{0, 128}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bucketsTouchedByRange(tt.from, tt.to)
+			if !int64SlicesEqual(got, tt.want) {
+				t.Fatalf("bucketsTouchedByRange(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func int64SlicesEqual(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
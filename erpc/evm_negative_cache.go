@@ -0,0 +1,105 @@
+package erpc
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// defaultNegativeVoteTTL bounds how long an open (not-yet-quorum) vote set is
+// kept before it's swept, so a key that never reaches quorum (e.g. quorum
+// configured higher than the number of upstreams that will ever answer a
+// given request) doesn't accumulate in memory for the life of the process.
+const defaultNegativeVoteTTL = 5 * time.Minute
+
+// negativeCacheEntry wraps an empty/negative result ([], null, 0x, etc.) so
+// Get can distinguish "we cached a confirmed-empty result" from "we never
+// wrote anything for this key", which an un-tagged empty value can't do.
+type negativeCacheEntry struct {
+	Negative bool            `json:"__erpc_negative__"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// decodeNegativeCacheEntry returns the wrapped value and true if raw is a
+// negativeCacheEntry; otherwise it returns false without error, since a plain
+// (non-negative) cached value is a perfectly normal thing to see here.
+func decodeNegativeCacheEntry(raw string) (negativeCacheEntry, bool) {
+	var entry negativeCacheEntry
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		return negativeCacheEntry{}, false
+	}
+	if !entry.Negative {
+		return negativeCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// negativeVoteSet is the open vote set for a single cache key: the distinct
+// upstreams that have reported an empty result for it so far, and when that
+// round of voting started (so it can be swept if it never reaches quorum).
+type negativeVoteSet struct {
+	voters   map[string]struct{}
+	openedAt time.Time
+}
+
+// negativeResultTracker counts, per cache key, how many distinct upstreams
+// have independently reported the same empty result. A negative result is
+// only promoted into the real cache once a configurable quorum of upstreams
+// agree, so a single lagging/misconfigured node can't poison the cache with
+// a false "nothing here" for every other client. Vote sets that stay open
+// (never reach quorum) longer than voteTTL are swept on the next call, so
+// keys that will never reach quorum don't accumulate forever.
+type negativeResultTracker struct {
+	mu      sync.Mutex
+	voters  map[string]*negativeVoteSet
+	voteTTL time.Duration
+}
+
+func newNegativeResultTracker() *negativeResultTracker {
+	return newNegativeResultTrackerWithTTL(defaultNegativeVoteTTL)
+}
+
+func newNegativeResultTrackerWithTTL(voteTTL time.Duration) *negativeResultTracker {
+	return &negativeResultTracker{voters: make(map[string]*negativeVoteSet), voteTTL: voteTTL}
+}
+
+// recordAndCheckQuorum records that upstreamId reported an empty result for
+// key, and reports whether at least quorum distinct upstreams have now
+// agreed. Once quorum is reached the vote set for that key is cleared, so a
+// later and unrelated round of votes starts fresh. A vote set older than
+// voteTTL that still hasn't reached quorum is treated as expired and
+// restarted from scratch rather than kept open indefinitely.
+func (t *negativeResultTracker) recordAndCheckQuorum(key string, upstreamId string, quorum int) bool {
+	if quorum <= 1 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.sweepExpiredLocked(now)
+
+	set, ok := t.voters[key]
+	if !ok {
+		set = &negativeVoteSet{voters: make(map[string]struct{}), openedAt: now}
+		t.voters[key] = set
+	}
+	set.voters[upstreamId] = struct{}{}
+
+	if len(set.voters) < quorum {
+		return false
+	}
+	delete(t.voters, key)
+	return true
+}
+
+// sweepExpiredLocked drops vote sets that have been open longer than
+// voteTTL without reaching quorum. Callers must hold t.mu.
+func (t *negativeResultTracker) sweepExpiredLocked(now time.Time) {
+	for key, set := range t.voters {
+		if now.Sub(set.openedAt) > t.voteTTL {
+			delete(t.voters, key)
+		}
+	}
+}
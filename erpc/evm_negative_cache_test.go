@@ -0,0 +1,90 @@
+package erpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordAndCheckQuorum(t *testing.T) {
+	tr := newNegativeResultTracker()
+
+	if tr.recordAndCheckQuorum("key1", "upstreamA", 2) {
+		t.Fatalf("expected quorum not reached after a single vote")
+	}
+	if !tr.recordAndCheckQuorum("key1", "upstreamB", 2) {
+		t.Fatalf("expected quorum reached after two distinct upstreams voted")
+	}
+}
+
+func TestRecordAndCheckQuorumSameUpstreamDoesNotDoubleCount(t *testing.T) {
+	tr := newNegativeResultTracker()
+
+	if tr.recordAndCheckQuorum("key1", "upstreamA", 2) {
+		t.Fatalf("expected quorum not reached after a single vote")
+	}
+	if tr.recordAndCheckQuorum("key1", "upstreamA", 2) {
+		t.Fatalf("expected quorum not reached when the same upstream votes again")
+	}
+}
+
+func TestRecordAndCheckQuorumResetsAfterBeingReached(t *testing.T) {
+	tr := newNegativeResultTracker()
+
+	tr.recordAndCheckQuorum("key1", "upstreamA", 2)
+	tr.recordAndCheckQuorum("key1", "upstreamB", 2)
+
+	// A later, unrelated round of votes for the same key starts fresh.
+	if tr.recordAndCheckQuorum("key1", "upstreamC", 2) {
+		t.Fatalf("expected a fresh round to require quorum again")
+	}
+}
+
+// TestRecordAndCheckQuorumKeysMustIncludeNetwork guards the bug where an
+// un-scoped cache key (method+params only) let votes for identical requests
+// on different networks count toward the same quorum. The cache must build
+// its quorum key as "networkId:cacheHash", not the bare cacheHash.
+func TestRecordAndCheckQuorumKeysMustIncludeNetwork(t *testing.T) {
+	tr := newNegativeResultTracker()
+
+	sameCacheHash := "abc123"
+	networkAKey := "evm:1:" + sameCacheHash
+	networkBKey := "evm:137:" + sameCacheHash
+
+	if tr.recordAndCheckQuorum(networkAKey, "upstreamA", 2) {
+		t.Fatalf("expected quorum not reached after a single vote on network A")
+	}
+	// A vote for the same cache hash on a different network must not count
+	// toward network A's quorum.
+	if tr.recordAndCheckQuorum(networkBKey, "upstreamB", 2) {
+		t.Fatalf("expected quorum not reached from an unrelated network's vote")
+	}
+	if !tr.recordAndCheckQuorum(networkAKey, "upstreamC", 2) {
+		t.Fatalf("expected quorum reached after a second distinct upstream voted on network A")
+	}
+}
+
+// TestRecordAndCheckQuorumExpiresStaleVoteSets guards against unbounded
+// memory growth from keys that never reach quorum: a vote set older than the
+// tracker's TTL must be dropped and restarted, not kept open forever.
+func TestRecordAndCheckQuorumExpiresStaleVoteSets(t *testing.T) {
+	tr := newNegativeResultTrackerWithTTL(10 * time.Millisecond)
+
+	tr.recordAndCheckQuorum("key1", "upstreamA", 2)
+	if len(tr.voters) != 1 {
+		t.Fatalf("expected one open vote set, got %d", len(tr.voters))
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	// A later call (even for an unrelated key) sweeps the expired vote set.
+	tr.recordAndCheckQuorum("key2", "upstreamB", 2)
+	if _, stillOpen := tr.voters["key1"]; stillOpen {
+		t.Fatalf("expected expired vote set for key1 to have been swept")
+	}
+
+	// Since key1's vote set expired, the same upstream voting again starts a
+	// fresh round rather than being a no-op against a stale entry.
+	if tr.recordAndCheckQuorum("key1", "upstreamA", 2) {
+		t.Fatalf("expected quorum not reached on a fresh round after expiry")
+	}
+}
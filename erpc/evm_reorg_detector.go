@@ -0,0 +1,182 @@
+package erpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// ReorgDetectorConfig controls how aggressively an EvmReorgDetector watches a
+// network's head for chain reorganizations before invalidating cache entries.
+type ReorgDetectorConfig struct {
+	// Enabled toggles reorg detection for the network this detector is attached to.
+	Enabled bool
+	// Depth is how many recent canonical blocks are kept in the ring buffer;
+	// a reorg deeper than this will not be detected.
+	Depth int
+}
+
+// canonicalBlock is a single entry in the reorg detector's ring buffer.
+type canonicalBlock struct {
+	Number     int64
+	Hash       string
+	ParentHash string
+}
+
+// EvmNewHeadsSource is the minimal surface a network must expose so a reorg
+// detector can watch its head without depending on the full upstream client.
+type EvmNewHeadsSource interface {
+	EvmSubscribeNewHeads(ctx context.Context) (<-chan canonicalBlock, error)
+}
+
+// EvmReorgDetector tracks the canonical chain of new heads for a network and,
+// when a reorg is observed, invalidates the cache groups for every block that
+// was orphaned so stale entries are never served again.
+type EvmReorgDetector struct {
+	cfg     *ReorgDetectorConfig
+	cache   *EvmJsonRpcCache
+	network *Network
+	logger  *zerolog.Logger
+
+	mu    sync.Mutex
+	ring  []canonicalBlock
+	byNum map[int64]int // block number -> index in ring, for O(1) parent lookups
+}
+
+// NewEvmReorgDetector creates a detector bound to a single network's cache.
+func NewEvmReorgDetector(logger *zerolog.Logger, cfg *ReorgDetectorConfig, cache *EvmJsonRpcCache, network *Network) *EvmReorgDetector {
+	if cfg.Depth <= 0 {
+		cfg.Depth = 128
+	}
+	return &EvmReorgDetector{
+		cfg:     cfg,
+		cache:   cache,
+		network: network,
+		logger:  logger,
+		byNum:   make(map[int64]int),
+	}
+}
+
+// blockGroupKeys returns the cache group keys an orphaned block's entries
+// were stored under: the number-keyed group (hex-encoded, matching the
+// blockRef format generateKeysForJsonRpcRequest uses for number-addressed
+// methods) and the hash-keyed group (which also covers any hash-based
+// tx/receipt entries cached for transactions included in this block, since
+// those are keyed by the same block hash as their blockRef).
+func blockGroupKeys(networkId string, b canonicalBlock) []string {
+	return []string{
+		fmt.Sprintf("%s:0x%x", networkId, b.Number),
+		fmt.Sprintf("%s:%s", networkId, b.Hash),
+	}
+}
+
+// OnNewHead is called for every new head observed on the network. It appends
+// the block to the ring buffer and, if a reorg is detected (the new block's
+// parent does not match the block we previously saw at that height), purges
+// the cache for every orphaned block and any transactions indexed under it.
+func (d *EvmReorgDetector) OnNewHead(ctx context.Context, number int64, hash string, parentHash string) error {
+	if !d.cfg.Enabled {
+		return nil
+	}
+
+	orphaned := d.recordAndDetect(number, hash, parentHash)
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	groupKeys := make([]string, 0, len(orphaned)*2)
+	for _, b := range orphaned {
+		groupKeys = append(groupKeys, blockGroupKeys(d.network.NetworkId(), b)...)
+	}
+
+	d.logger.Warn().
+		Str("networkId", d.network.NetworkId()).
+		Int("orphanedBlocks", len(orphaned)).
+		Int64("reorgFrom", orphaned[0].Number).
+		Msg("reorg detected, invalidating cache for orphaned blocks")
+
+	return d.cache.DeleteByGroupKey(ctx, groupKeys...)
+}
+
+// Watch subscribes to source's new heads and feeds each one into OnNewHead
+// until ctx is canceled or the subscription closes. source is supplied by
+// the caller (whoever constructs the detector already has a concrete client
+// capable of subscribing to new heads) rather than discovered from the
+// network, so this only ever runs when a real source was actually wired up -
+// see ReorgDetectorConfig(); callers should only launch Watch once
+// cfg.Enabled is true and they have a source to pass it.
+func (d *EvmReorgDetector) Watch(ctx context.Context, source EvmNewHeadsSource) error {
+	if !d.cfg.Enabled {
+		return nil
+	}
+
+	heads, err := source.EvmSubscribeNewHeads(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case head, ok := <-heads:
+			if !ok {
+				return nil
+			}
+			if err := d.OnNewHead(ctx, head.Number, head.Hash, head.ParentHash); err != nil {
+				d.logger.Warn().Err(err).Int64("blockNumber", head.Number).Msg("failed to process new head for reorg detection")
+			}
+		}
+	}
+}
+
+// recordAndDetect appends the new head to the ring buffer and returns the set
+// of previously-canonical blocks that are no longer part of the chain, walking
+// back until a matching parent hash is found (or the ring buffer is exhausted).
+func (d *EvmReorgDetector) recordAndDetect(number int64, hash string, parentHash string) []canonicalBlock {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var orphaned []canonicalBlock
+
+	parentMismatch := false
+	if idx, ok := d.byNum[number-1]; ok && d.ring[idx].Hash != parentHash {
+		parentMismatch = true
+	}
+	sameHeightMismatch := false
+	if idx, ok := d.byNum[number]; ok && d.ring[idx].Hash != hash {
+		sameHeightMismatch = true
+	}
+
+	if parentMismatch || sameHeightMismatch {
+		for i := len(d.ring) - 1; i >= 0; i-- {
+			b := d.ring[i]
+			if b.Number >= number {
+				// anything previously recorded at this height or above is
+				// being replaced by the new head, so it's orphaned too
+				orphaned = append(orphaned, b)
+				continue
+			}
+			if b.Hash == parentHash {
+				break
+			}
+			orphaned = append(orphaned, b)
+		}
+	}
+
+	d.ring = append(d.ring, canonicalBlock{Number: number, Hash: hash, ParentHash: parentHash})
+	if len(d.ring) > d.cfg.Depth {
+		evicted := d.ring[0]
+		d.ring = d.ring[1:]
+		delete(d.byNum, evicted.Number)
+		for i := range d.ring {
+			d.byNum[d.ring[i].Number] = i
+		}
+	} else {
+		d.byNum[number] = len(d.ring) - 1
+	}
+
+	return orphaned
+}
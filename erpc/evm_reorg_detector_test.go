@@ -0,0 +1,74 @@
+package erpc
+
+import "testing"
+
+func newTestReorgDetector(depth int) *EvmReorgDetector {
+	return &EvmReorgDetector{
+		cfg:   &ReorgDetectorConfig{Depth: depth},
+		byNum: make(map[int64]int),
+	}
+}
+
+func TestRecordAndDetectNoReorg(t *testing.T) {
+	d := newTestReorgDetector(128)
+
+	d.recordAndDetect(1, "0xh1", "0xh0")
+	orphaned := d.recordAndDetect(2, "0xh2", "0xh1")
+
+	if len(orphaned) != 0 {
+		t.Fatalf("expected no orphaned blocks on a consistent chain, got %v", orphaned)
+	}
+}
+
+func TestRecordAndDetectSimpleReorg(t *testing.T) {
+	d := newTestReorgDetector(128)
+
+	d.recordAndDetect(1, "0xh1", "0xh0")
+	d.recordAndDetect(2, "0xh2a", "0xh1")
+
+	// Block 2 is replaced by a competing block with the same parent.
+	orphaned := d.recordAndDetect(2, "0xh2b", "0xh1")
+
+	if len(orphaned) != 1 || orphaned[0].Hash != "0xh2a" {
+		t.Fatalf("expected block 0xh2a to be orphaned, got %v", orphaned)
+	}
+}
+
+func TestRecordAndDetectDeeperReorg(t *testing.T) {
+	d := newTestReorgDetector(128)
+
+	d.recordAndDetect(1, "0xh1", "0xh0")
+	d.recordAndDetect(2, "0xh2", "0xh1")
+	d.recordAndDetect(3, "0xh3", "0xh2")
+
+	// New block 3 forks off block 1, orphaning both 2 and 3.
+	orphaned := d.recordAndDetect(3, "0xh3b", "0xh1")
+
+	if len(orphaned) != 2 {
+		t.Fatalf("expected 2 orphaned blocks, got %d: %v", len(orphaned), orphaned)
+	}
+}
+
+func TestRecordAndDetectEvictsBeyondDepth(t *testing.T) {
+	d := newTestReorgDetector(2)
+
+	d.recordAndDetect(1, "0xh1", "0xh0")
+	d.recordAndDetect(2, "0xh2", "0xh1")
+	d.recordAndDetect(3, "0xh3", "0xh2")
+
+	if len(d.ring) != 2 {
+		t.Fatalf("expected ring buffer capped at depth 2, got %d entries", len(d.ring))
+	}
+	if _, ok := d.byNum[1]; ok {
+		t.Fatalf("expected block 1 to have been evicted from byNum")
+	}
+}
+
+func TestBlockGroupKeysUsesHexBlockNumber(t *testing.T) {
+	keys := blockGroupKeys("evm:1", canonicalBlock{Number: 300, Hash: "0xabc"})
+
+	want := []string{"evm:1:0x12c", "evm:1:0xabc"}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] {
+		t.Fatalf("blockGroupKeys = %v, want %v", keys, want)
+	}
+}